@@ -0,0 +1,34 @@
+package local
+
+import (
+	"strings"
+
+	"github.com/restic/restic/internal/errors"
+)
+
+// Config holds all information needed to open a local repository.
+type Config struct {
+	Path   string
+	Layout string `option:"layout" help:"use this backend directory layout (default: auto-detect)"`
+
+	// Fsync is the durability mode: "full", "data" or "none".
+	Fsync string `option:"fsync" help:"set fsync mode (full, data, none, default: full)"`
+}
+
+// NewConfig returns a new Config with the default values filled in.
+func NewConfig() Config {
+	return Config{
+		Fsync: "full",
+	}
+}
+
+// ParseConfig parses a local backend config.
+func ParseConfig(s string) (interface{}, error) {
+	if !strings.HasPrefix(s, "local:") {
+		return nil, errors.New("invalid local backend path")
+	}
+
+	cfg := NewConfig()
+	cfg.Path = s[len("local:"):]
+	return &cfg, nil
+}
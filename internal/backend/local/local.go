@@ -44,9 +44,29 @@ func dirExists(name string) bool {
 	return fi.IsDir()
 }
 
+// validFsyncModes are the values Config.Fsync accepts; "" is also allowed
+// and means the same as "full".
+var validFsyncModes = map[string]bool{"": true, "full": true, "data": true, "none": true}
+
+// validateFsync rejects an unrecognized cfg.Fsync and normalizes the zero
+// value to "full".
+func validateFsync(cfg *Config) error {
+	if !validFsyncModes[cfg.Fsync] {
+		return errors.Errorf("invalid fsync mode %q, must be one of full, data, none", cfg.Fsync)
+	}
+	if cfg.Fsync == "" {
+		cfg.Fsync = "full"
+	}
+	return nil
+}
+
 // Open opens the local backend as specified by config.
 func Open(cfg Config) (*Local, error) {
 	debug.Log("open local backend at %v (layout %q)", cfg.Path, cfg.Layout)
+	if err := validateFsync(&cfg); err != nil {
+		return nil, err
+	}
+
 	l, err := backend.ParseLayout(&backend.LocalFilesystem{}, cfg.Layout, defaultLayout, cfg.Path)
 	if err != nil {
 		return nil, err
@@ -79,6 +99,9 @@ func Open(cfg Config) (*Local, error) {
 // backend at dir. Afterwards a new config blob should be created.
 func Create(cfg Config) (*Local, error) {
 	debug.Log("create local backend at %v (layout %q)", cfg.Path, cfg.Layout)
+	if err := validateFsync(&cfg); err != nil {
+		return nil, err
+	}
 
 	l, err := backend.ParseLayout(&backend.LocalFilesystem{}, cfg.Layout, defaultLayout, cfg.Path)
 	if err != nil {
@@ -117,49 +140,104 @@ func (b *Local) IsNotExist(err error) bool {
 	return os.IsNotExist(errors.Cause(err))
 }
 
-// Save stores data in the backend at the handle.
+// contextReader checks ctx before each Read and returns ctx's error once it
+// is done, so an in-progress io.Copy stops at its next Read after the
+// context is cancelled instead of running to completion. A Read that is
+// already in progress is not interrupted.
+type contextReader struct {
+	ctx context.Context
+	io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.Reader.Read(p)
+}
+
+// Save stores data in the backend at the handle. It writes to a temporary
+// file next to the final destination and links it into place once the
+// data is written (and, unless Fsync is "none", synced), so Save never
+// leaves a partial file behind
+// and never clobbers an existing blob.
 func (b *Local) Save(ctx context.Context, h restic.Handle, rd io.Reader) (err error) {
 	debug.Log("Save %v", h)
 	if err := h.Valid(); err != nil {
 		return err
 	}
 
+	dir := b.Dirname(h)
 	if h.Type == restic.LockFile {
-		lockDir := b.Dirname(h)
-		if !dirExists(lockDir) {
+		if !dirExists(dir) {
 			debug.Log("locks/ does not exist yet, creating now.")
-			if err := fs.MkdirAll(lockDir, backend.Modes.Dir); err != nil {
+			if err := fs.MkdirAll(dir, backend.Modes.Dir); err != nil {
 				return errors.Wrap(err, "MkdirAll")
 			}
 		}
 	}
 
-	filename := b.Filename(h)
+	finalname := b.Filename(h)
 
-	// create new file
-	f, err := fs.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, backend.Modes.File)
+	// create a temporary file in the same directory as the final name, so
+	// that the link below is an atomic, same-filesystem operation
+	f, err := os.CreateTemp(dir, filepath.Base(finalname)+"-tmp-")
 	if err != nil {
-		return errors.Wrap(err, "OpenFile")
+		return errors.Wrap(err, "TempFile")
 	}
+	tmpname := f.Name()
+
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = fs.Remove(tmpname)
+		}
+	}()
 
 	// save data, then sync
-	_, err = io.Copy(f, rd)
+	_, err = io.Copy(f, contextReader{ctx, rd})
 	if err != nil {
-		_ = f.Close()
 		return errors.Wrap(err, "Write")
 	}
 
-	if err = f.Sync(); err != nil {
-		_ = f.Close()
-		return errors.Wrap(err, "Sync")
+	if b.Fsync != "none" {
+		if err = f.Sync(); err != nil {
+			return errors.Wrap(err, "Sync")
+		}
 	}
 
-	err = f.Close()
-	if err != nil {
+	if err = f.Close(); err != nil {
 		return errors.Wrap(err, "Close")
 	}
 
-	return setNewFileMode(filename, backend.Modes.File)
+	if err = setNewFileMode(tmpname, backend.Modes.File); err != nil {
+		return err
+	}
+
+	// Local is a content-addressable store: a file at finalname already
+	// means the blob exists. Link, unlike Rename, fails atomically with
+	// EEXIST if finalname is already there, so two concurrent Saves of the
+	// same handle can never clobber each other.
+	if err = fs.Link(tmpname, finalname); err != nil {
+		if os.IsExist(errors.Cause(err)) {
+			return errors.Errorf("Save: file %v already exists", finalname)
+		}
+		return errors.Wrap(err, "Link")
+	}
+
+	if err = fs.Remove(tmpname); err != nil {
+		return errors.Wrap(err, "Remove")
+	}
+
+	// make sure the new link is durable: fsync the directory unless the
+	// user traded that guarantee away for throughput
+	if b.Fsync == "full" {
+		if err = fs.SyncDir(dir); err != nil {
+			return errors.Wrap(err, "syncDir")
+		}
+	}
+
+	return nil
 }
 
 // Load returns a reader that yields the contents of the file at h at the
@@ -235,43 +313,110 @@ func (b *Local) Remove(ctx context.Context, h restic.Handle) error {
 		return errors.Wrap(err, "Chmod")
 	}
 
-	return fs.Remove(fn)
+	if err = fs.Remove(fn); err != nil {
+		return err
+	}
+
+	if b.Fsync == "full" {
+		if err = fs.SyncDir(b.Dirname(h)); err != nil {
+			return errors.Wrap(err, "syncDir")
+		}
+	}
+
+	return nil
 }
 
-func isFile(fi os.FileInfo) bool {
-	return fi.Mode()&(os.ModeType|os.ModeCharDevice) == 0
+func isFile(mode os.FileMode) bool {
+	return mode&os.ModeType == 0
 }
 
-// List returns a channel that yields all names of blobs of type t. A
-// goroutine is started for this.
-func (b *Local) List(ctx context.Context, t restic.FileType) <-chan string {
+// List runs fn for each file of type t in the backend, in lexical order
+// per directory, so callers can do range scans and resume a listing from a
+// given name. If fn returns an error, List is aborted and the error is
+// returned.
+func (b *Local) List(ctx context.Context, t restic.FileType, fn func(restic.FileInfo) error) error {
 	debug.Log("List %v", t)
+	return b.listSubdirs(ctx, b.Basedir(t), fn)
+}
 
-	ch := make(chan string)
+// listSubdirs visits the entries directly within basedir. Most file types
+// are stored flat, but layouts like the default one shard data files into
+// subdirectories, so a directory entry is recursed into (one level, not
+// assumed to only ever occur for data files) rather than skipped.
+func (b *Local) listSubdirs(ctx context.Context, basedir string, fn func(restic.FileInfo) error) error {
+	entries, err := os.ReadDir(basedir)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil
+		}
+		return err
+	}
 
-	go func() {
-		defer close(ch)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-		fs.Walk(b.Basedir(t), func(path string, fi os.FileInfo, err error) error {
-			if err != nil {
+		if entry.IsDir() {
+			if err := b.listDir(ctx, filepath.Join(basedir, entry.Name()), fn); err != nil {
 				return err
 			}
+			continue
+		}
 
-			if !isFile(fi) {
-				return err
-			}
+		if !isFile(entry.Type()) {
+			continue
+		}
 
-			select {
-			case ch <- filepath.Base(path):
-			case <-ctx.Done():
-				return err
-			}
+		if err := b.fileInfo(entry, fn); err != nil {
+			return err
+		}
+	}
 
+	return nil
+}
+
+// listDir calls fn for every file directly within dir, in lexical order.
+func (b *Local) listDir(ctx context.Context, dir string, fn func(restic.FileInfo) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
 			return err
-		})
-	}()
+		}
+
+		if !isFile(entry.Type()) {
+			continue
+		}
+
+		if err := b.fileInfo(entry, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileInfo stats entry to get its size and passes name and size to fn. This
+// still costs one stat per file, the same as the old fs.Walk-based List;
+// the saving here is skipping that stat for directory entries, which is
+// cheap relative to the millions of files a repo's pack directory can hold.
+func (b *Local) fileInfo(entry os.DirEntry, fn func(restic.FileInfo) error) error {
+	fi, err := entry.Info()
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil
+		}
+		return err
+	}
 
-	return ch
+	return fn(restic.FileInfo{Name: entry.Name(), Size: fi.Size()})
 }
 
 // Delete removes the repository and all files.
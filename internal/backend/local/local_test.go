@@ -0,0 +1,158 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/restic/restic/internal/restic"
+)
+
+var errTest = errors.New("test error")
+
+// testLayout is a minimal backend.Layout that stores every handle directly
+// under a single root directory, named after the handle's blob ID.
+type testLayout struct {
+	root string
+}
+
+func (l testLayout) Dirname(h restic.Handle) string { return l.root }
+func (l testLayout) Filename(h restic.Handle) string {
+	return filepath.Join(l.root, h.Name)
+}
+func (l testLayout) Basedir(t restic.FileType) string { return l.root }
+func (l testLayout) Paths() []string                  { return []string{l.root} }
+func (l testLayout) Name() string                     { return "test" }
+
+func newTestLocal(t *testing.T) *Local {
+	dir := t.TempDir()
+
+	return &Local{
+		Config: Config{Path: dir, Fsync: "full"},
+		Layout: testLayout{root: dir},
+	}
+}
+
+func TestSaveDoesNotClobberExistingBlob(t *testing.T) {
+	b := newTestLocal(t)
+	h := restic.Handle{Type: restic.DataFile, Name: strings.Repeat("ab", 32)}
+
+	if err := b.Save(context.Background(), h, strings.NewReader("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := b.Save(context.Background(), h, strings.NewReader("second"))
+	if err == nil {
+		t.Fatal("expected Save to reject an already-existing blob, got nil error")
+	}
+
+	data, err := os.ReadFile(b.Filename(h))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first" {
+		t.Fatalf("Save clobbered the existing blob: got %q, want %q", data, "first")
+	}
+}
+
+func TestSaveLeavesNoTempFileOnCancelledContext(t *testing.T) {
+	b := newTestLocal(t)
+	h := restic.Handle{Type: restic.DataFile, Name: strings.Repeat("cd", 32)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := b.Save(ctx, h, strings.NewReader("data")); err == nil {
+		t.Fatal("expected Save to fail for a cancelled context")
+	}
+
+	entries, err := os.ReadDir(b.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Save left unexpected files behind: %v", entries)
+	}
+}
+
+func TestListLexicalOrder(t *testing.T) {
+	b := newTestLocal(t)
+
+	names := []string{"ffff", "0001", "aaaa", "0100"}
+	for _, name := range names {
+		h := restic.Handle{Type: restic.DataFile, Name: name}
+		if err := b.Save(context.Background(), h, strings.NewReader(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	err := b.List(context.Background(), restic.DataFile, func(fi restic.FileInfo) error {
+		got = append(got, fi.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"0001", "0100", "aaaa", "ffff"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("List did not return entries in lexical order: got %v, want %v", got, want)
+	}
+}
+
+func TestListPropagatesCallbackError(t *testing.T) {
+	b := newTestLocal(t)
+
+	h := restic.Handle{Type: restic.DataFile, Name: strings.Repeat("12", 32)}
+	if err := b.Save(context.Background(), h, strings.NewReader("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	wantErr := errTest
+	err := b.List(context.Background(), restic.DataFile, func(restic.FileInfo) error {
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("List did not propagate the callback error: got %v, want %v", err, wantErr)
+	}
+}
+
+func TestOpenRejectsInvalidFsyncMode(t *testing.T) {
+	_, err := Open(Config{Path: t.TempDir(), Fsync: "ful"})
+	if err == nil {
+		t.Fatal("expected Open to reject an invalid fsync mode, got nil error")
+	}
+}
+
+func TestListDescendsIntoShardedSubdirs(t *testing.T) {
+	// the default layout shards data files into subdirectories; List must
+	// still find them alongside any files stored flat in the same layout.
+	b := newTestLocal(t)
+
+	if err := os.MkdirAll(filepath.Join(b.Path, "ab"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(b.Path, "ab", "cdef"), []byte("sharded"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(b.Path, "flatfile"), []byte("flat"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err := b.List(context.Background(), restic.DataFile, func(fi restic.FileInfo) error {
+		got = append(got, fi.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("List did not find both the sharded and the flat file: got %v", got)
+	}
+}
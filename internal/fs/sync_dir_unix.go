@@ -0,0 +1,21 @@
+// +build !windows
+
+package fs
+
+// SyncDir flushes changes to the directory dir, e.g. a file creation,
+// rename or removal, so that the change survives a crash. On Windows,
+// where directories cannot be fsynced, this is a no-op.
+func SyncDir(dir string) error {
+	d, err := Open(dir)
+	if err != nil {
+		return err
+	}
+
+	err = d.Sync()
+	if err != nil {
+		_ = d.Close()
+		return err
+	}
+
+	return d.Close()
+}
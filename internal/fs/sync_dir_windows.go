@@ -0,0 +1,9 @@
+// +build windows
+
+package fs
+
+// SyncDir is a no-op on Windows, which does not support fsyncing a
+// directory handle.
+func SyncDir(dir string) error {
+	return nil
+}